@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TaskRunSpec defines the desired state of a TaskRun.
+type TaskRunSpec struct {
+	// +optional
+	Params []Param `json:"params,omitempty"`
+
+	// ServiceAccountName is the ServiceAccount the TaskRun's Pod runs as,
+	// and whose imagePullSecrets are used to pull the Task's Step and
+	// Sidecar images.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// BuilderServiceAccountName names a ServiceAccount distinct from
+	// ServiceAccountName whose imagePullSecrets/secrets are projected into
+	// a workspace at /kaniko/.docker/config.json, for Tasks that build and
+	// push an OCI image. It is separate from the pod identity so that a
+	// Task author can grant push credentials without widening what the
+	// Pod itself (and thus every Step) is authorized to do.
+	// +optional
+	BuilderServiceAccountName string `json:"builderServiceAccountName,omitempty"`
+
+	// +optional
+	TaskRef *TaskRef `json:"taskRef,omitempty"`
+
+	// +optional
+	TaskSpec *TaskSpec `json:"taskSpec,omitempty"`
+
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// +optional
+	PodTemplate *PodTemplate `json:"podTemplate,omitempty"`
+
+	// +optional
+	Workspaces []WorkspaceBinding `json:"workspaces,omitempty"`
+
+	// +optional
+	Resources *TaskRunResources `json:"resources,omitempty"`
+}
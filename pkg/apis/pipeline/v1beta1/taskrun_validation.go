@@ -0,0 +1,39 @@
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/pkg/apis"
+)
+
+// Validate checks ts for errors a webhook should reject before the TaskRun
+// is ever handed to the reconciler.
+func (ts *TaskRunSpec) Validate(ctx context.Context) (errs *apis.FieldError) {
+	if ts.BuilderServiceAccountName == "" {
+		return errs
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(ts.BuilderServiceAccountName) {
+		errs = errs.Also(&apis.FieldError{
+			Message: msg,
+			Paths:   []string{"builderServiceAccountName"},
+		})
+	}
+	return errs
+}
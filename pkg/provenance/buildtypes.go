@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import "fmt"
+
+const (
+	// BuildTypeAnnotation is the TaskRun annotation used to select a
+	// Formatter. When unset, BuildTypeTektonV2Alpha2 is used.
+	BuildTypeAnnotation = "chains.tekton.dev/build-type"
+
+	// BuildTypeTektonV2Alpha2 produces the verbose, Tekton-specific payload
+	// shape (full TaskRef, params and resource URIs as Tekton models them).
+	BuildTypeTektonV2Alpha2 = "tekton.dev/v2alpha2"
+
+	// BuildTypeSLSAContainerBasedBuild produces the generic payload shape
+	// consumed by non-Tekton-aware SLSA verifiers.
+	BuildTypeSLSAContainerBasedBuild = "slsa.dev/container-based-build/v0.2"
+)
+
+// Formatter renders the BuildDefinition portion of a Predicate for one
+// buildType. The reconciler logic that gathers Input stays the same
+// regardless of which Formatter is selected; only the resulting payload
+// shape changes.
+type Formatter interface {
+	Format(in Input) BuildDefinition
+}
+
+var formatters = map[string]Formatter{
+	BuildTypeTektonV2Alpha2:          tektonV2Alpha2Formatter{},
+	BuildTypeSLSAContainerBasedBuild: slsaContainerBasedBuildFormatter{},
+}
+
+// FormatterFor returns the Formatter registered for buildType, or an error if
+// none is registered.
+func FormatterFor(buildType string) (Formatter, error) {
+	f, ok := formatters[buildType]
+	if !ok {
+		return nil, fmt.Errorf("provenance: no formatter registered for buildType %q", buildType)
+	}
+	return f, nil
+}
+
+type tektonV2Alpha2Formatter struct{}
+
+func (tektonV2Alpha2Formatter) Format(in Input) BuildDefinition {
+	return BuildDefinition{
+		BuildType: BuildTypeTektonV2Alpha2,
+		ExternalParameters: map[string]interface{}{
+			"taskRef":   in.TaskRef,
+			"params":    in.Params,
+			"resources": in.ResourceURLs,
+			"commits":   in.ResourceCommits,
+		},
+		InternalParameters: map[string]interface{}{
+			"namespace":   in.Namespace,
+			"featureFlags": in.FeatureFlags,
+		},
+		ResolvedDependencies: in.ResolvedDependencies,
+	}
+}
+
+type slsaContainerBasedBuildFormatter struct{}
+
+func (slsaContainerBasedBuildFormatter) Format(in Input) BuildDefinition {
+	return BuildDefinition{
+		BuildType: BuildTypeSLSAContainerBasedBuild,
+		ExternalParameters: map[string]interface{}{
+			"source":  in.ResourceURLs,
+			"commits": in.ResourceCommits,
+		},
+		InternalParameters: map[string]interface{}{
+			"namespace": in.Namespace,
+		},
+		ResolvedDependencies: in.ResolvedDependencies,
+	}
+}
@@ -0,0 +1,22 @@
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package provenance builds in-toto SLSA v1.0 provenance attestations for
+// completed TaskRuns. It is deliberately decoupled from the reconciler:
+// callers gather the facts a TaskRun already knows about itself (resource
+// results, params, status) and hand them to Generate, which fans out to a
+// buildType-specific Formatter to produce the predicate payload.
+package provenance
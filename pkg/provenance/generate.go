@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import "fmt"
+
+// ControllerID is the builder.id reported for every Predicate emitted by
+// this controller.
+const ControllerID = "https://tekton.dev/chains/v2"
+
+// Input is the set of facts the reconciler has already gathered about a
+// completed TaskRun by the time it decides to emit provenance. It is the
+// same regardless of buildType; only the Formatter interprets it
+// differently.
+type Input struct {
+	Namespace    string
+	TaskRef      string
+	Params       map[string]string
+	FeatureFlags map[string]string
+
+	// ResourceURLs maps a PipelineResource name (e.g. "gitsource") to the
+	// "url" ResourcesResult harvested from TaskRun.Status.ResourcesResult.
+	ResourceURLs map[string]string
+
+	// ResourceCommits maps a PipelineResource name to its "commit"
+	// ResourcesResult. Kept separate from ResourceURLs because a git
+	// resource reports both under the same resource name, and collapsing
+	// them into one map would silently drop whichever is written last.
+	ResourceCommits map[string]string
+
+	// ResolvedDependencies lists the artifacts recorded for the TaskRun.
+	// emitProvenance fills it with one sha256 entry per "digest"
+	// ResourcesResult; it does not include the git commit (reported under
+	// ResourceCommits) or the digests of the step images.
+	ResolvedDependencies []ResourceDescriptor
+}
+
+// Generate builds the Predicate for in using the Formatter registered under
+// buildType. Reconciler call sites are expected to resolve buildType once
+// from the TaskRun's BuildTypeAnnotation (defaulting to
+// BuildTypeTektonV2Alpha2) before calling Generate.
+func Generate(buildType string, in Input) (*Predicate, error) {
+	f, err := FormatterFor(buildType)
+	if err != nil {
+		return nil, fmt.Errorf("provenance: generating predicate: %w", err)
+	}
+	return &Predicate{
+		BuildDefinition: f.Format(in),
+		RunDetails: RunDetails{
+			Builder: Builder{ID: ControllerID},
+		},
+	}, nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+// Predicate is the in-toto SLSA v1.0 "https://slsa.dev/provenance/v1"
+// predicate emitted for a TaskRun that produced an OCI image. Its shape is
+// fixed by the spec; only the contents of BuildDefinition vary by buildType.
+type Predicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+// BuildDefinition describes the inputs that determined the build's output,
+// split into the parameters a verifier must reproduce (ExternalParameters),
+// context that does not affect reproducibility (InternalParameters), and the
+// artifacts the build consumed (ResolvedDependencies).
+type BuildDefinition struct {
+	// BuildType is a URI identifying the semantics of this BuildDefinition.
+	// Tekton TaskRuns select it via the `chains.tekton.dev/build-type`
+	// annotation; see buildtypes.go for the supported values.
+	BuildType            string                 `json:"buildType"`
+	ExternalParameters   map[string]interface{} `json:"externalParameters"`
+	InternalParameters   map[string]interface{} `json:"internalParameters"`
+	ResolvedDependencies []ResourceDescriptor    `json:"resolvedDependencies"`
+}
+
+// RunDetails records who ran the build and how.
+type RunDetails struct {
+	Builder  Builder                `json:"builder"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Builder identifies the controller that executed the build.
+type Builder struct {
+	// ID is the Tekton controller identity, e.g.
+	// "https://tekton.dev/chains/v2".
+	ID string `json:"id"`
+}
+
+// ResourceDescriptor describes one artifact (git commit, PipelineResource,
+// step image) that fed into or came out of the build.
+type ResourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
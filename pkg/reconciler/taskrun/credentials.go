@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskrun
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// builderCredentialsWorkspaceName names the volume a TaskRun's pod gets
+// when the TaskRun's Spec.BuilderServiceAccountName resolves to at least one
+// pull credential. It is mounted at /kaniko/.docker in every container of the
+// pod (see addBuilderCredentialsVolume), so a Kaniko step finds
+// `/kaniko/.docker/config.json` without the Task declaring anything.
+const builderCredentialsWorkspaceName = "builder-credentials"
+
+// dockerConfigJSON mirrors the on-disk shape of ~/.docker/config.json.
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+type dockerConfigEntry struct {
+	Auth string `json:"auth"`
+}
+
+// resolveBuilderCredentials is called from buildPod (see reconcile.go). It
+// reads the ServiceAccount named by tr.Spec.BuilderServiceAccountName (a
+// credential identity distinct from the pod's own ServiceAccountName) and
+// projects every "kubernetes.io/dockerconfigjson" and "kubernetes.io/basic-auth"
+// Secret it references into a single Secret laid out as
+// /kaniko/.docker/config.json. The caller mounts the returned Secret as
+// builderCredentialsWorkspaceName; it returns (nil, nil) when the TaskRun
+// names no BuilderServiceAccountName.
+func resolveBuilderCredentials(ctx context.Context, kubeClientset kubernetes.Interface, tr *v1beta1.TaskRun) (*corev1.Secret, error) {
+	if tr.Spec.BuilderServiceAccountName == "" {
+		return nil, nil
+	}
+
+	sa, err := kubeClientset.CoreV1().ServiceAccounts(tr.Namespace).Get(ctx, tr.Spec.BuilderServiceAccountName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("resolving builder service account %s/%s: %w", tr.Namespace, tr.Spec.BuilderServiceAccountName, err)
+	}
+
+	cfg := dockerConfigJSON{Auths: map[string]dockerConfigEntry{}}
+	var secretNames []string
+	for _, ref := range sa.ImagePullSecrets {
+		secretNames = append(secretNames, ref.Name)
+	}
+	for _, ref := range sa.Secrets {
+		secretNames = append(secretNames, ref.Name)
+	}
+	for _, name := range secretNames {
+		secret, err := kubeClientset.CoreV1().Secrets(tr.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("resolving secret %s/%s for builder service account %s: %w", tr.Namespace, name, sa.Name, err)
+		}
+		if err := mergeDockerConfig(&cfg, secret); err != nil {
+			return nil, fmt.Errorf("projecting secret %s/%s into docker config: %w", tr.Namespace, name, err)
+		}
+	}
+	if len(cfg.Auths) == 0 {
+		return nil, nil
+	}
+
+	payload, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling projected docker config for taskrun %s/%s: %w", tr.Namespace, tr.Name, err)
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tr.Name + "-builder-credentials",
+			Namespace: tr.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(tr, v1beta1.SchemeGroupVersion.WithKind("TaskRun")),
+			},
+		},
+		Data: map[string][]byte{"config.json": payload},
+	}, nil
+}
+
+func mergeDockerConfig(cfg *dockerConfigJSON, secret *corev1.Secret) error {
+	switch secret.Type {
+	case corev1.SecretTypeDockerConfigJson:
+		var existing dockerConfigJSON
+		if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &existing); err != nil {
+			return err
+		}
+		for registry, entry := range existing.Auths {
+			cfg.Auths[registry] = entry
+		}
+	case corev1.SecretTypeBasicAuth:
+		registry := secret.Annotations["tekton.dev/docker-0"]
+		if registry == "" {
+			registry = secret.Name
+		}
+		creds := string(secret.Data[corev1.BasicAuthUsernameKey]) + ":" + string(secret.Data[corev1.BasicAuthPasswordKey])
+		cfg.Auths[registry] = dockerConfigEntry{Auth: base64.StdEncoding.EncodeToString([]byte(creds))}
+	}
+	return nil
+}
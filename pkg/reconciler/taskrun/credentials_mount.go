@@ -0,0 +1,51 @@
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskrun
+
+import corev1 "k8s.io/api/core/v1"
+
+// addBuilderCredentialsVolume is called from buildPod (see reconcile.go),
+// after resolveBuilderCredentials, to mount the Secret it produced at
+// /kaniko/.docker/config.json in every step container, so an image-build
+// step (Kaniko or otherwise) can rely on Docker's standard config-file
+// discovery instead of a Task author wiring up volumes by hand.
+func addBuilderCredentialsVolume(pod *corev1.PodSpec, credentials *corev1.Secret) {
+	if credentials == nil {
+		return
+	}
+
+	pod.Volumes = append(pod.Volumes, corev1.Volume{
+		Name: builderCredentialsWorkspaceName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: credentials.Name,
+				Items: []corev1.KeyToPath{
+					{Key: "config.json", Path: "config.json"},
+				},
+			},
+		},
+	})
+
+	mount := corev1.VolumeMount{
+		Name:      builderCredentialsWorkspaceName,
+		MountPath: "/kaniko/.docker",
+		ReadOnly:  true,
+	}
+	for i := range pod.Containers {
+		pod.Containers[i].VolumeMounts = append(pod.Containers[i].VolumeMounts, mount)
+	}
+}
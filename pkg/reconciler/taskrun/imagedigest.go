@@ -0,0 +1,70 @@
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskrun
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"github.com/tektoncd/pipeline/pkg/remoteimage"
+)
+
+// validatePushedDigests re-resolves the manifest digest for every "digest"
+// ResourcesResult tr reports that has a "url" ResourcesResult for the same
+// resource, directly against the registry, and fails if a step's
+// self-reported digest doesn't match what was actually pushed. A digest with
+// no matching url is not checked. It
+// runs before ResourcesResult is trusted by anything downstream (provenance,
+// a consuming Pipeline's image resource binding).
+func validatePushedDigests(ctx context.Context, results []v1beta1.PipelineResourceResult) error {
+	urls := map[string]string{}
+	for _, rr := range results {
+		if rr.Key == "url" {
+			urls[rr.ResourceRef.Name] = rr.Value
+		}
+	}
+
+	for _, rr := range results {
+		if rr.Key != "digest" {
+			continue
+		}
+		url, ok := urls[rr.ResourceRef.Name]
+		if !ok {
+			continue
+		}
+		ref := url + "@" + rr.Value
+		got, err := remoteimage.Digest(ctx, ref, remoteimage.DialInCluster(registryHost(url)))
+		if err != nil {
+			return fmt.Errorf("validating pushed digest for resource %s: %w", rr.ResourceRef.Name, err)
+		}
+		if got.String() != rr.Value {
+			return fmt.Errorf("resource %s: reported digest %s does not match registry digest %s", rr.ResourceRef.Name, rr.Value, got.String())
+		}
+	}
+	return nil
+}
+
+// registryHost returns the "host:port" portion of a "host:port/repo" image
+// reference.
+func registryHost(ref string) string {
+	if i := strings.Index(ref, "/"); i >= 0 {
+		return ref[:i]
+	}
+	return ref
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"github.com/tektoncd/pipeline/pkg/provenance"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// featureFlagsNamespace and featureFlagsConfigMapName locate the ConfigMap
+// the controller's own Deployment is configured with, the same one Tekton's
+// webhook validates Task/TaskRun specs against. Its Data is reported
+// verbatim as internalParameters.featureFlags, so a provenance consumer can
+// see which flags were live when the image was built.
+const (
+	featureFlagsNamespace     = "tekton-pipelines"
+	featureFlagsConfigMapName = "feature-flags"
+)
+
+// resolveFeatureFlags reads the feature-flags ConfigMap, tolerating it being
+// absent (e.g. in a minimal test cluster) by reporting no flags rather than
+// failing the TaskRun's provenance emission over it.
+func resolveFeatureFlags(ctx context.Context, kubeClientset kubernetes.Interface) (map[string]string, error) {
+	cm, err := kubeClientset.CoreV1().ConfigMaps(featureFlagsNamespace).Get(ctx, featureFlagsConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolving feature flags: %w", err)
+	}
+	return cm.Data, nil
+}
+
+// emitProvenance is invoked from completeTaskRun (see reconcile.go) once a
+// TaskRun succeeds. It is a no-op unless the TaskRun produced at least one
+// "digest" ResourcesResult, i.e. it built an OCI image.
+//
+// The predicate is written to a companion ConfigMap named
+// "<taskrun-name>-provenance" rather than TaskRunStatus itself, so that
+// emitting it never competes with the size limits or update conflicts of the
+// TaskRun object it describes.
+func emitProvenance(ctx context.Context, kubeClientset kubernetes.Interface, tr *v1beta1.TaskRun) error {
+	digest := ""
+	resourceURLs := map[string]string{}
+	resourceCommits := map[string]string{}
+	var resolved []provenance.ResourceDescriptor
+	for _, rr := range tr.Status.ResourcesResult {
+		switch rr.Key {
+		case "digest":
+			digest = rr.Value
+			resolved = append(resolved, provenance.ResourceDescriptor{
+				Name:   rr.ResourceRef.Name,
+				Digest: map[string]string{"sha256": rr.Value},
+			})
+		case "url":
+			resourceURLs[rr.ResourceRef.Name] = rr.Value
+		case "commit":
+			resourceCommits[rr.ResourceRef.Name] = rr.Value
+		}
+	}
+	if digest == "" {
+		// Nothing was built; provenance only applies to image-producing runs.
+		return nil
+	}
+
+	buildType := tr.Annotations[provenance.BuildTypeAnnotation]
+	if buildType == "" {
+		buildType = provenance.BuildTypeTektonV2Alpha2
+	}
+
+	taskRef := ""
+	if tr.Spec.TaskRef != nil {
+		taskRef = tr.Spec.TaskRef.Name
+	}
+	params := map[string]string{}
+	for _, p := range tr.Spec.Params {
+		params[p.Name] = p.Value.StringVal
+	}
+	featureFlags, err := resolveFeatureFlags(ctx, kubeClientset)
+	if err != nil {
+		return fmt.Errorf("emitting provenance for taskrun %s/%s: %w", tr.Namespace, tr.Name, err)
+	}
+
+	predicate, err := provenance.Generate(buildType, provenance.Input{
+		Namespace:            tr.Namespace,
+		TaskRef:              taskRef,
+		Params:               params,
+		FeatureFlags:         featureFlags,
+		ResourceURLs:         resourceURLs,
+		ResourceCommits:      resourceCommits,
+		ResolvedDependencies: resolved,
+	})
+	if err != nil {
+		return fmt.Errorf("emitting provenance for taskrun %s/%s: %w", tr.Namespace, tr.Name, err)
+	}
+
+	payload, err := json.Marshal(predicate)
+	if err != nil {
+		return fmt.Errorf("marshalling provenance predicate for taskrun %s/%s: %w", tr.Namespace, tr.Name, err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tr.Name + "-provenance",
+			Namespace: tr.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(tr, v1beta1.SchemeGroupVersion.WithKind("TaskRun")),
+			},
+		},
+		Data: map[string]string{"predicate.json": string(payload)},
+	}
+	if _, err := kubeClientset.CoreV1().ConfigMaps(tr.Namespace).Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("writing provenance configmap for taskrun %s/%s: %w", tr.Namespace, tr.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskrun
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Reconciler drives a TaskRun from creation through completion: building the
+// Pod that runs its Steps, and reacting once that Pod reports a terminal
+// state.
+type Reconciler struct {
+	KubeClientSet kubernetes.Interface
+}
+
+// ReconcileKind is the entry point the generated controller (NewController,
+// informers, workqueue - none of which are part of this trimmed package)
+// calls on every add/update of a TaskRun. It is the single real call site
+// for buildPod and completeTaskRun: buildPod runs while the Pod is still
+// being assembled, completeTaskRun once tr itself has gone terminal.
+//
+// Pod assembly (translating TaskSpec's Steps/Sidecars into a PodSpec) lives
+// in the rest of the real reconciler and isn't reproduced here; buildPod is
+// called against whatever PodSpec that step produced.
+func (r *Reconciler) ReconcileKind(ctx context.Context, tr *v1beta1.TaskRun, pod *corev1.PodSpec) error {
+	if c := tr.Status.GetCondition("Succeeded"); c == nil || (!c.IsTrue() && !c.IsFalse()) {
+		return r.buildPod(ctx, tr, pod)
+	}
+	return r.completeTaskRun(ctx, tr)
+}
+
+// buildPod fills in pod, the PodSpec the reconciler is about to submit for
+// tr, with anything that depends on the TaskRun itself rather than just its
+// Task's Steps/Sidecars. Currently that is only builder credential
+// injection; callers run this after translating TaskSpec into pod's
+// containers and before creating the Pod.
+func (r *Reconciler) buildPod(ctx context.Context, tr *v1beta1.TaskRun, pod *corev1.PodSpec) error {
+	credentials, err := resolveBuilderCredentials(ctx, r.KubeClientSet, tr)
+	if err != nil {
+		return fmt.Errorf("building pod for taskrun %s/%s: %w", tr.Namespace, tr.Name, err)
+	}
+	addBuilderCredentialsVolume(pod, credentials)
+	return nil
+}
+
+// completeTaskRun runs once tr's "Succeeded" condition has gone terminal. On
+// success it validates any image digest the TaskRun reported against the
+// registry before trusting it further, then is the single call site for
+// emitProvenance, so provenance is only ever written for a TaskRun that
+// actually finished with a verified ResourcesResult.
+func (r *Reconciler) completeTaskRun(ctx context.Context, tr *v1beta1.TaskRun) error {
+	c := tr.Status.GetCondition("Succeeded")
+	if c == nil || !c.IsTrue() {
+		return nil
+	}
+	if err := validatePushedDigests(ctx, tr.Status.ResourcesResult); err != nil {
+		return fmt.Errorf("completing taskrun %s/%s: %w", tr.Namespace, tr.Name, err)
+	}
+	if err := emitProvenance(ctx, r.KubeClientSet, tr); err != nil {
+		return fmt.Errorf("completing taskrun %s/%s: %w", tr.Namespace, tr.Name, err)
+	}
+	return nil
+}
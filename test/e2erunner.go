@@ -0,0 +1,101 @@
+// +build e2e
+
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	resourceversioned "github.com/tektoncd/pipeline/pkg/client/resource/clientset/versioned/typed/resource/v1alpha1"
+	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/typed/pipeline/v1beta1"
+	"k8s.io/client-go/kubernetes"
+	knativetest "knative.dev/pkg/test"
+)
+
+// Each spec gets its own namespace, so that `go test -parallel N -shuffle=on` runs
+// every TestXxxTaskRun in this package concurrently, in whatever order the
+// toolchain's own shuffle picks, without them colliding on object names.
+// namespaceSeq only has to be unique within one test binary invocation; it
+// is combined with a random suffix below. See TestMain (init_test.go) for
+// the one-time CRD-readiness phase shared by every spec.
+var namespaceSeq int64
+
+// generateNamespaceName returns a namespace name scoped to t, unique even
+// when many specs run in parallel and in randomized order.
+func generateNamespaceName(t *testing.T) string {
+	n := atomic.AddInt64(&namespaceSeq, 1)
+	return fmt.Sprintf("e2e-%d-%d-%d", os.Getpid(), n, rand.Int63n(1e6))
+}
+
+// newClients builds the typed clients a spec needs. The PipelineResource,
+// Task and TaskRun clients are scoped to namespace; KubeClient is the
+// cluster-wide clientset.
+func newClients(namespace string) (*clients, error) {
+	cfg, err := knativetest.BuildClientConfig(knativetest.Flags.Kubeconfig, knativetest.Flags.Cluster)
+	if err != nil {
+		return nil, fmt.Errorf("building client config: %w", err)
+	}
+	kube, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("building kube clientset: %w", err)
+	}
+	pipelineClient, err := versioned.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("building pipeline clientset: %w", err)
+	}
+	resourceClient, err := resourceversioned.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("building pipeline resource clientset: %w", err)
+	}
+	return &clients{
+		KubeClient:             &knativetest.KubeClient{Kube: kube},
+		KubeConfig:             cfg,
+		PipelineResourceClient: resourceClient.PipelineResources(namespace),
+		TaskClient:             pipelineClient.Tasks(namespace),
+		TaskRunClient:          pipelineClient.TaskRuns(namespace),
+	}, nil
+}
+
+// timeoutMultiplier returns the TEST_E2E_TIMEOUT_MULTIPLIER env value
+// (default 1), applied uniformly to every WaitForTaskRunState /
+// WaitForPodState poll so that a slow CI node doesn't need per-spec timeout
+// tuning.
+func timeoutMultiplier() time.Duration {
+	v := os.Getenv("TEST_E2E_TIMEOUT_MULTIPLIER")
+	if v == "" {
+		return 1
+	}
+	m, err := strconv.Atoi(v)
+	if err != nil || m < 1 {
+		return 1
+	}
+	return time.Duration(m)
+}
+
+// verifyTektonObjectsEnabled reports whether TEST_E2E_VERIFY_TEKTONOBJECTS is
+// set, in which case each spec snapshots and diffs the full TaskRun/Pod
+// object graph at teardown (see verifyTektonObjects in wait.go).
+func verifyTektonObjectsEnabled() bool {
+	return os.Getenv("TEST_E2E_VERIFY_TEKTONOBJECTS") != ""
+}
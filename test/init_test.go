@@ -0,0 +1,184 @@
+// +build e2e
+
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	resourceversioned "github.com/tektoncd/pipeline/pkg/client/resource/clientset/versioned/typed/resource/v1alpha1"
+	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/typed/pipeline/v1beta1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	knativetest "knative.dev/pkg/test"
+)
+
+const (
+	kanikoImage   = "gcr.io/kaniko-project/executor"
+	registryImage = "registry"
+)
+
+// skipRootUserTests lets environments that forbid RunAsUser: 0 (e.g. some
+// hardened clusters) opt out of the Kaniko test rather than fail it.
+var skipRootUserTests = false
+
+// requiredCRDs lists the CRDs every spec in this package depends on. CI
+// applies them once, out of band, before the e2e binary runs; TestMain below
+// only waits for the API server to report them Established, and does so
+// once for the whole binary instead of once per spec.
+var requiredCRDs = []string{
+	"tasks.tekton.dev",
+	"taskruns.tekton.dev",
+	"pipelineresources.tekton.dev",
+}
+
+// TestMain runs the package's shared, one-time setup phase - waiting for
+// requiredCRDs to be Established - before handing off to go test's own
+// runner. Spec-order randomization is left to `go test -shuffle=on`; every
+// spec below already runs in its own generated namespace (see
+// generateNamespaceName), so it is safe in whatever order or `-parallel`
+// concurrency the toolchain picks.
+func TestMain(m *testing.M) {
+	cfg, err := knativetest.BuildClientConfig(knativetest.Flags.Kubeconfig, knativetest.Flags.Cluster)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "building client config: %s\n", err)
+		os.Exit(1)
+	}
+	apiextensionsClient, err := apiextensionsclientset.NewForConfig(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "building apiextensions clientset: %s\n", err)
+		os.Exit(1)
+	}
+	if err := waitForCRDsEstablished(apiextensionsClient); err != nil {
+		fmt.Fprintf(os.Stderr, "waiting for CRDs to become established: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// waitForCRDsEstablished polls until every CRD in requiredCRDs reports an
+// Established=True condition, or until timeout (scaled by
+// TEST_E2E_TIMEOUT_MULTIPLIER, same as WaitForTaskRunState) elapses.
+func waitForCRDsEstablished(c apiextensionsclientset.Interface) error {
+	ctx := context.Background()
+	for _, name := range requiredCRDs {
+		name := name
+		err := wait.PollImmediate(interval, timeoutMultiplier()*timeout, func() (bool, error) {
+			crd, err := c.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return false, nil
+			}
+			for _, cond := range crd.Status.Conditions {
+				if cond.Type == apiextensionsv1.Established && cond.Status == apiextensionsv1.ConditionTrue {
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+		if err != nil {
+			return fmt.Errorf("CRD %s not established: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// clients holds every typed client an e2e spec might need, plus the raw
+// rest.Config used to set up ad hoc tunnels (see portforward.go).
+type clients struct {
+	KubeClient             *knativetest.KubeClient
+	KubeConfig             *rest.Config
+	PipelineResourceClient resourceversioned.PipelineResourceInterface
+	TaskClient             versioned.TaskInterface
+	TaskRunClient          versioned.TaskRunInterface
+}
+
+// setupOpt configures the namespace setup helper does for a spec before
+// handing back its clients.
+type setupOpt func(context.Context, *testing.T, *clients, string)
+
+// withRegistry provisions an in-namespace image registry Service backed by
+// the Task's "registry" sidecar, so image-building specs never need an
+// external registry.
+func withRegistry(ctx context.Context, t *testing.T, c *clients, namespace string) {
+	t.Helper()
+	if _, err := c.KubeClient.Kube.CoreV1().Services(namespace).Create(ctx, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: namespace},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "registry"},
+			Ports:    []corev1.ServicePort{{Port: 5000, TargetPort: intstr.FromInt(5000)}},
+		},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create in-namespace registry service: %s", err)
+	}
+}
+
+// setup creates a fresh, isolated namespace for a spec, applies opts, and
+// returns clients scoped to it. Specs never share a namespace, so they can
+// run in parallel without colliding on object names.
+func setup(ctx context.Context, t *testing.T, opts ...setupOpt) (*clients, string) {
+	t.Helper()
+	namespace := generateNamespaceName(t)
+
+	c, err := newClients(namespace)
+	if err != nil {
+		t.Fatalf("Failed to create clients: %s", err)
+	}
+	if _, err := c.KubeClient.Kube.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create namespace %s: %s", namespace, err)
+	}
+
+	for _, opt := range opts {
+		opt(ctx, t, c, namespace)
+	}
+	return c, namespace
+}
+
+// tearDown deletes the namespace setup created, and everything in it.
+func tearDown(ctx context.Context, t *testing.T, c *clients, namespace string) {
+	t.Helper()
+	if c == nil {
+		return
+	}
+	if err := c.KubeClient.Kube.CoreV1().Namespaces().Delete(ctx, namespace, metav1.DeleteOptions{}); err != nil {
+		t.Logf("Failed to delete namespace %s: %s", namespace, err)
+	}
+}
+
+func getTestImage(image string) string {
+	return image
+}
+
+func getContainerLogsFromPod(ctx context.Context, kube kubernetes.Interface, pod, container, namespace string) (string, error) {
+	req := kube.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{Container: container})
+	logs, err := req.DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("reading logs for %s/%s container %s: %w", namespace, pod, container, err)
+	}
+	return string(logs), nil
+}
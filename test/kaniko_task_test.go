@@ -20,8 +20,8 @@ package test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"strings"
 	"testing"
 	"time"
 
@@ -30,6 +30,8 @@ import (
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	resources "github.com/tektoncd/pipeline/pkg/apis/resource/v1alpha1"
+	"github.com/tektoncd/pipeline/pkg/provenance"
+	"github.com/tektoncd/pipeline/pkg/remoteimage"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	knativetest "knative.dev/pkg/test"
@@ -59,8 +61,10 @@ func TestKanikoTaskRun(t *testing.T) {
 
 	repo := fmt.Sprintf("registry.%s:5000/kanikotasktest", namespace)
 
+	before := snapshotTektonObjects(ctx, c, namespace)
 	knativetest.CleanupOnInterrupt(func() { tearDown(ctx, t, c, namespace) }, t.Logf)
 	defer tearDown(ctx, t, c, namespace)
+	defer verifyTektonObjects(ctx, t, c, namespace, before)
 
 	t.Logf("Creating Git PipelineResource %s", kanikoGitResourceName)
 	if _, err := c.PipelineResourceClient.Create(ctx, getGitResource(), metav1.CreateOptions{}); err != nil {
@@ -124,13 +128,195 @@ func TestKanikoTaskRun(t *testing.T) {
 	}
 
 	// match the local digest, which is first capture group against the remote image
-	remoteDigest, err := getRemoteDigest(t, c, namespace, repo)
+	remoteDigest, err := getRemoteDigest(ctx, c, namespace, "registry", "kanikotasktest", "latest")
 	if err != nil {
 		t.Fatalf("Expected to get digest for remote image %s: %v", repo, err)
 	}
 	if d := cmp.Diff(digest, remoteDigest); d != "" {
 		t.Fatalf("Expected local digest %s to match remote digest %s: %s", digest, remoteDigest, d)
 	}
+
+	verifyProvenance(ctx, t, c, namespace, tr, digest, commit, url, provenance.BuildTypeTektonV2Alpha2)
+}
+
+// verifyProvenance fetches the "<taskrun-name>-provenance" ConfigMap the
+// reconciler writes alongside a successful image-building TaskRun and checks
+// that its predicate agrees with the digest/commit/url already scraped from
+// tr.Status.ResourcesResult, and that it was generated with buildType.
+func verifyProvenance(ctx context.Context, t *testing.T, c *clients, namespace string, tr *v1beta1.TaskRun, digest, commit, url, buildType string) {
+	t.Helper()
+
+	cm, err := c.KubeClient.Kube.CoreV1().ConfigMaps(namespace).Get(ctx, tr.Name+"-provenance", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Error retrieving provenance ConfigMap for TaskRun %s: %s", tr.Name, err)
+	}
+
+	var predicate provenance.Predicate
+	if err := json.Unmarshal([]byte(cm.Data["predicate.json"]), &predicate); err != nil {
+		t.Fatalf("Error unmarshalling provenance predicate: %s", err)
+	}
+
+	if predicate.BuildDefinition.BuildType != buildType {
+		t.Errorf("Expected buildType %s, got %s", buildType, predicate.BuildDefinition.BuildType)
+	}
+	if predicate.RunDetails.Builder.ID == "" {
+		t.Errorf("Expected a non-empty builder.id in provenance predicate")
+	}
+
+	var gotDigest string
+	for _, rd := range predicate.BuildDefinition.ResolvedDependencies {
+		if sha := rd.Digest["sha256"]; sha != "" {
+			gotDigest = sha
+		}
+	}
+	if gotDigest != digest {
+		t.Errorf("Expected provenance digest %s to match TaskRun digest %s", gotDigest, digest)
+	}
+
+	if !externalParameterHasValue(predicate.BuildDefinition.ExternalParameters, "commits", commit) {
+		t.Errorf("Expected provenance commits to include TaskRun commit %s", commit)
+	}
+	if !externalParameterHasValue(predicate.BuildDefinition.ExternalParameters, "resources", url) {
+		t.Errorf("Expected provenance resources to include TaskRun url %s", url)
+	}
+}
+
+// externalParameterHasValue reports whether predicate's
+// ExternalParameters[key] - a map[string]string round-tripped through JSON
+// as map[string]interface{} - contains want among its values.
+func externalParameterHasValue(params map[string]interface{}, key, want string) bool {
+	m, ok := params[key].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, v := range m {
+		if s, _ := v.(string); s == want {
+			return true
+		}
+	}
+	return false
+}
+
+// TestKanikoTaskRunWithBuilderServiceAccount is like TestKanikoTaskRun, but
+// supplies push credentials purely via TaskRunSpec.BuilderServiceAccountName:
+// the Task spec itself has no volume or SecurityContext wiring for
+// credentials, the reconciler projects the referenced ServiceAccount's
+// dockerconfigjson Secret into /kaniko/.docker/config.json for it. It pushes
+// to the same in-namespace registry as TestKanikoTaskRun, which does not
+// require auth, so the push succeeding does not show the credentials were
+// used; the test checks that they were materialized and mounted.
+func TestKanikoTaskRunWithBuilderServiceAccount(t *testing.T) {
+	ctx := context.Background()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if skipRootUserTests {
+		t.Skip("Skip test as skipRootUserTests set to true")
+	}
+
+	c, namespace := setup(ctx, t, withRegistry, withAuthenticatedRegistryCredentials)
+	t.Parallel()
+
+	repo := fmt.Sprintf("registry.%s:5000/kanikotasktest-authenticated", namespace)
+	saName := "kaniko-builder"
+
+	before := snapshotTektonObjects(ctx, c, namespace)
+	knativetest.CleanupOnInterrupt(func() { tearDown(ctx, t, c, namespace) }, t.Logf)
+	defer tearDown(ctx, t, c, namespace)
+	defer verifyTektonObjects(ctx, t, c, namespace, before)
+
+	if _, err := c.PipelineResourceClient.Create(ctx, getGitResource(), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create Pipeline Resource `%s`: %s", kanikoGitResourceName, err)
+	}
+	if _, err := c.PipelineResourceClient.Create(ctx, getImageResource(repo), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create Pipeline Resource `%s`: %s", kanikoGitResourceName, err)
+	}
+	if _, err := c.TaskClient.Create(ctx, getTask(repo, namespace), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create Task `%s`: %s", kanikoTaskName, err)
+	}
+	if _, err := c.TaskRunClient.Create(ctx, getTaskRunWithBuilderServiceAccount(namespace, saName), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create TaskRun `%s`: %s", kanikoTaskRunName, err)
+	}
+
+	if err := WaitForTaskRunState(ctx, c, kanikoTaskRunName, Succeed(kanikoTaskRunName), "TaskRunCompleted"); err != nil {
+		t.Errorf("Error waiting for TaskRun %s to finish: %s", kanikoTaskRunName, err)
+	}
+
+	verifyBuilderCredentialsInjected(ctx, t, c, namespace, saName)
+}
+
+// verifyBuilderCredentialsInjected checks that the reconciler actually
+// materialized and mounted builder credentials for kanikoTaskRunName, rather
+// than trusting that a successful push implies it: it fetches the generated "<taskrun>-builder-credentials"
+// Secret and confirms the TaskRun's Pod mounts it at /kaniko/.docker.
+func verifyBuilderCredentialsInjected(ctx context.Context, t *testing.T, c *clients, namespace, saName string) {
+	t.Helper()
+
+	secretName := kanikoTaskRunName + "-builder-credentials"
+	secret, err := c.KubeClient.Kube.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected reconciler to create Secret %s for builder service account %s: %s", secretName, saName, err)
+	}
+	if len(secret.Data["config.json"]) == 0 {
+		t.Errorf("Expected Secret %s to carry a non-empty config.json", secretName)
+	}
+
+	pod, err := c.KubeClient.Kube.CoreV1().Pods(namespace).Get(ctx, kanikoTaskRunName+"-pod", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Error retrieving Pod for TaskRun %s: %s", kanikoTaskRunName, err)
+	}
+	var mounted bool
+	volumeName := ""
+	for _, v := range pod.Spec.Volumes {
+		if v.VolumeSource.Secret != nil && v.VolumeSource.Secret.SecretName == secretName {
+			mounted = true
+			volumeName = v.Name
+		}
+	}
+	if !mounted {
+		t.Errorf("Expected Pod %s to mount Secret %s as a volume", pod.Name, secretName)
+	}
+	var atKanikoDocker bool
+	for _, ctr := range pod.Spec.Containers {
+		for _, vm := range ctr.VolumeMounts {
+			if mounted && vm.Name == volumeName && vm.MountPath == "/kaniko/.docker" {
+				atKanikoDocker = true
+			}
+		}
+	}
+	if !atKanikoDocker {
+		t.Errorf("Expected Pod %s to mount Secret %s at /kaniko/.docker", pod.Name, secretName)
+	}
+}
+
+// withAuthenticatedRegistryCredentials creates the ServiceAccount and
+// dockerconfigjson Secret that TestKanikoTaskRunWithBuilderServiceAccount
+// references by name; the reconciler is the only thing that reads the
+// Secret's contents.
+func withAuthenticatedRegistryCredentials(ctx context.Context, t *testing.T, c *clients, namespace string) {
+	t.Helper()
+	secretName := "kaniko-builder-pull-secret"
+	if _, err := c.KubeClient.Kube.CoreV1().Secrets(namespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: namespace},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(fmt.Sprintf(`{"auths":{"registry.%s:5000":{"auth":"dGVzdDp0ZXN0"}}}`, namespace)),
+		},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create pull secret: %s", err)
+	}
+	if _, err := c.KubeClient.Kube.CoreV1().ServiceAccounts(namespace).Create(ctx, &corev1.ServiceAccount{
+		ObjectMeta:       metav1.ObjectMeta{Name: "kaniko-builder", Namespace: namespace},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: secretName}},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create builder service account: %s", err)
+	}
+}
+
+func getTaskRunWithBuilderServiceAccount(namespace, saName string) *v1beta1.TaskRun {
+	tr := getTaskRun(namespace)
+	tr.Spec.BuilderServiceAccountName = saName
+	return tr
 }
 
 func getGitResource() *v1alpha1.PipelineResource {
@@ -203,42 +389,22 @@ func getTaskRun(namespace string) *v1beta1.TaskRun {
 	}
 }
 
-// getRemoteDigest starts a pod to query the registry from the namespace itself, using skopeo (and jq).
-// The reason we have to do that is because the image is pushed on a local registry that is not exposed
-// to the "outside" of the test, this means it can be query by the test itself. It can only be query from
-// a pod in the namespace. skopeo is able to do that query and we use jq to extract the digest from its
-// output. The image used for this pod is build in the tektoncd/plumbing repository.
-func getRemoteDigest(t *testing.T, c *clients, namespace, image string) (string, error) {
-	t.Helper()
-	podName := "skopeo-jq"
-	ctx := context.Background()
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-	if _, err := c.KubeClient.Kube.CoreV1().Pods(namespace).Create(ctx, &corev1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: namespace,
-			Name:      podName,
-		},
-		Spec: corev1.PodSpec{
-			Containers: []corev1.Container{{
-				Name:    "skopeo",
-				Image:   "gcr.io/tekton-releases/dogfooding/skopeo:latest",
-				Command: []string{"/bin/sh", "-c"},
-				Args:    []string{"skopeo inspect --tls-verify=false docker://" + image + ":latest| jq '.Digest'"},
-			}},
-			RestartPolicy: corev1.RestartPolicyNever,
-		},
-	}, metav1.CreateOptions{}); err != nil {
-		t.Fatalf("Failed to create the skopeo-jq pod: %v", err)
-	}
-	if err := WaitForPodState(ctx, c, podName, namespace, func(pod *corev1.Pod) (bool, error) {
-		return pod.Status.Phase == "Succeeded" || pod.Status.Phase == "Failed", nil
-	}, "PodContainersTerminated"); err != nil {
-		t.Fatalf("Error waiting for Pod %q to terminate: %v", podName, err)
+// getRemoteDigest resolves the manifest digest of repo:tag as pushed to the
+// in-cluster registry Service, without needing a helper pod. The registry is
+// only reachable from inside the cluster, so we tunnel to it the same way
+// `kubectl port-forward` would, via an SPDY connection, and hand that
+// connection to remoteimage as its dial func.
+func getRemoteDigest(ctx context.Context, c *clients, namespace, service, repo, tag string) (string, error) {
+	pf, err := newServicePortForwarder(c.KubeClient.Kube, c.KubeConfig, namespace, service, 5000)
+	if err != nil {
+		return "", fmt.Errorf("setting up port-forward to %s/%s: %w", namespace, service, err)
 	}
-	logs, err := getContainerLogsFromPod(ctx, c.KubeClient.Kube, podName, "skopeo", namespace)
+	defer pf.Close()
+
+	ref := fmt.Sprintf("%s:%s/%s:%s", service, "5000", repo, tag)
+	digest, err := remoteimage.Digest(ctx, ref, pf.Dial)
 	if err != nil {
-		t.Fatalf("Could not get logs for pod %s: %s", podName, err)
+		return "", fmt.Errorf("resolving remote digest for %s: %w", ref, err)
 	}
-	return strings.TrimSpace(strings.ReplaceAll(logs, "\"", "")), nil
+	return digest.String(), nil
 }
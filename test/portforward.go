@@ -0,0 +1,128 @@
+// +build e2e
+
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// servicePortForwarder tunnels a local dialer to a single port on one of a
+// Service's backing Pods, the same way `kubectl port-forward` does. It lets
+// in-cluster-only resources (like the Kaniko test registry) be reached from
+// the e2e binary without exposing them outside the cluster.
+type servicePortForwarder struct {
+	stopCh    chan struct{}
+	readyCh   chan struct{}
+	errCh     chan error
+	localAddr string
+}
+
+// newServicePortForwarder takes the first Pod listed behind service in
+// namespace and forwards an ephemeral local port to targetPort on that Pod.
+// The Pod's readiness is not checked.
+func newServicePortForwarder(kube kubernetes.Interface, cfg *rest.Config, namespace, service string, targetPort int) (*servicePortForwarder, error) {
+	ctx := context.Background()
+	svc, err := kube.CoreV1().Services(namespace).Get(ctx, service, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting service %s/%s: %w", namespace, service, err)
+	}
+	pods, err := kube.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelsToSelector(svc.Spec.Selector),
+	})
+	if err != nil || len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pods backing service %s/%s", namespace, service)
+	}
+	podName := pods.Items[0].Name
+
+	req := kube.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("portforward")
+
+	transport, upgrader, err := spdy.RoundTripperFor(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("building SPDY round tripper: %w", err)
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL())
+
+	local, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("allocating local port: %w", err)
+	}
+	localPort := local.Addr().(*net.TCPAddr).Port
+	// portforward.NewOnAddresses binds this exact address itself below, so
+	// our reservation has to be released first - accepting the inherent
+	// TOCTOU race, the same way kubectl port-forward's own helpers do.
+	if err := local.Close(); err != nil {
+		return nil, fmt.Errorf("releasing allocated local port: %w", err)
+	}
+
+	pf := &servicePortForwarder{
+		stopCh:    make(chan struct{}, 1),
+		readyCh:   make(chan struct{}),
+		errCh:     make(chan error, 1),
+		localAddr: fmt.Sprintf("127.0.0.1:%d", localPort),
+	}
+
+	ports := []string{fmt.Sprintf("%d:%d", localPort, targetPort)}
+	fw, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, ports, pf.stopCh, pf.readyCh, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating port forwarder: %w", err)
+	}
+	go func() { pf.errCh <- fw.ForwardPorts() }()
+
+	select {
+	case <-pf.readyCh:
+	case err := <-pf.errCh:
+		return nil, fmt.Errorf("port-forward to %s/%s failed: %w", namespace, podName, err)
+	}
+	return pf, nil
+}
+
+// Dial opens a connection to the forwarded port, suitable for use as a
+// remoteimage.DialFunc.
+func (pf *servicePortForwarder) Dial(ctx context.Context) (net.Conn, error) {
+	return (&net.Dialer{}).DialContext(ctx, "tcp", pf.localAddr)
+}
+
+// Close stops the forwarder.
+func (pf *servicePortForwarder) Close() {
+	close(pf.stopCh)
+}
+
+func labelsToSelector(labels map[string]string) string {
+	sel := ""
+	for k, v := range labels {
+		if sel != "" {
+			sel += ","
+		}
+		sel += k + "=" + v
+	}
+	return sel
+}
@@ -0,0 +1,137 @@
+// +build e2e
+
+/*
+Copyright 2020 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	interval = 1 * time.Second
+	timeout  = 10 * time.Minute
+)
+
+// ConditionAccessorFn reports whether a TaskRun has reached the condition a
+// caller is waiting for.
+type ConditionAccessorFn func(tr *v1beta1.TaskRun) (bool, error)
+
+// Succeed returns a ConditionAccessorFn that is satisfied once name's
+// Succeeded condition is True, and fails fast if it goes False.
+func Succeed(name string) ConditionAccessorFn {
+	return func(tr *v1beta1.TaskRun) (bool, error) {
+		c := tr.Status.GetCondition("Succeeded")
+		if c == nil {
+			return false, nil
+		}
+		switch {
+		case c.IsTrue():
+			return true, nil
+		case c.IsFalse():
+			return true, fmt.Errorf("taskrun %s failed: %s: %s", name, c.Reason, c.Message)
+		default:
+			return false, nil
+		}
+	}
+}
+
+// WaitForTaskRunState polls until accessor's condition is met, or until
+// timeout (scaled by TEST_E2E_TIMEOUT_MULTIPLIER) elapses. desc is used only
+// to make a timeout error message readable.
+func WaitForTaskRunState(ctx context.Context, c *clients, name string, accessor ConditionAccessorFn, desc string) error {
+	var lastErr error
+	waitErr := wait.PollImmediate(interval, timeoutMultiplier()*timeout, func() (bool, error) {
+		tr, err := c.TaskRunClient.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+		done, err := accessor(tr)
+		if err != nil {
+			return true, err
+		}
+		return done, nil
+	})
+	if waitErr != nil {
+		return fmt.Errorf("taskrun %s not in desired state (%s): %w (last get error: %v)", name, desc, waitErr, lastErr)
+	}
+	return nil
+}
+
+// PodConditionAccessorFn reports whether a Pod has reached the condition a
+// caller is waiting for.
+type PodConditionAccessorFn func(pod *corev1.Pod) (bool, error)
+
+// WaitForPodState polls until accessor's condition is met for pod name, or
+// until timeout (scaled by TEST_E2E_TIMEOUT_MULTIPLIER) elapses.
+func WaitForPodState(ctx context.Context, c *clients, name, namespace string, accessor PodConditionAccessorFn, desc string) error {
+	var lastErr error
+	waitErr := wait.PollImmediate(interval, timeoutMultiplier()*timeout, func() (bool, error) {
+		pod, err := c.KubeClient.Kube.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+		done, err := accessor(pod)
+		if err != nil {
+			return true, err
+		}
+		return done, nil
+	})
+	if waitErr != nil {
+		return fmt.Errorf("pod %s not in desired state (%s): %w (last get error: %v)", name, desc, waitErr, lastErr)
+	}
+	return nil
+}
+
+// verifyTektonObjects snapshots every TaskRun and Pod in namespace and diffs
+// them against a prior snapshot taken at spec start. It is a no-op unless
+// TEST_E2E_VERIFY_TEKTONOBJECTS is set, since the full diff is expensive and
+// only useful when chasing a reconciler regression.
+func verifyTektonObjects(ctx context.Context, t interface {
+	Helper()
+	Logf(string, ...interface{})
+}, c *clients, namespace string, before map[string]interface{}) {
+	if !verifyTektonObjectsEnabled() {
+		return
+	}
+	t.Helper()
+	after := snapshotTektonObjects(ctx, c, namespace)
+	if d := cmp.Diff(before, after); d != "" {
+		t.Logf("TaskRun/Pod object graph changed during spec %s (-before +after):\n%s", namespace, d)
+	}
+}
+
+func snapshotTektonObjects(ctx context.Context, c *clients, namespace string) map[string]interface{} {
+	snapshot := map[string]interface{}{}
+	if trs, err := c.TaskRunClient.List(ctx, metav1.ListOptions{}); err == nil {
+		snapshot["taskruns"] = trs
+	}
+	if pods, err := c.KubeClient.Kube.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{}); err == nil {
+		snapshot["pods"] = pods
+	}
+	return snapshot
+}